@@ -0,0 +1,175 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	goruntime "runtime"
+	"sort"
+	"time"
+)
+
+// statsJSON is the shape emitted by --stats-format=json for a single run.
+type statsJSON struct {
+	GasUsed        uint64 `json:"gasUsed"`
+	ExecTimeNs     int64  `json:"execTimeNs"`
+	Allocs         int64  `json:"allocs"`
+	BytesAllocated int64  `json:"bytesAllocated"`
+	Output         string `json:"output"`
+	Error          string `json:"error,omitempty"`
+}
+
+// benchAggregate summarizes a series of --bench-count runs of the same
+// execution, since a single timed run collapses everything to an average
+// and throws away the variance a gas-regression
+// bot or a precompile/interpreter tuning session actually needs.
+type benchAggregate struct {
+	Runs     int     `json:"runs"`
+	MinNs    int64   `json:"minExecTimeNs"`
+	MedianNs int64   `json:"medianExecTimeNs"`
+	P95Ns    int64   `json:"p95ExecTimeNs"`
+	MaxNs    int64   `json:"maxExecTimeNs"`
+	MeanGas  float64 `json:"meanGasUsed"`
+
+	runStats  []execStats
+	gasPerRun []uint64 // gas left after each run; runCmd turns MeanGas into gas used
+}
+
+// runBenchN runs execFunc repeatedly, once per --bench-count, each run
+// executing for at least benchTime (or a single pass if benchTime is 0),
+// and returns the output/gas of the final run together with the
+// aggregated timing statistics across all runs.
+func runBenchN(execFunc func() ([]byte, uint64, error), count int, benchTime time.Duration) (output []byte, gasLeft uint64, agg *benchAggregate, err error) {
+	agg = &benchAggregate{Runs: count}
+	for i := 0; i < count; i++ {
+		var stats execStats
+		output, gasLeft, stats, err = runOnce(execFunc, benchTime)
+		if err != nil {
+			return output, gasLeft, agg, err
+		}
+		agg.runStats = append(agg.runStats, stats)
+		agg.gasPerRun = append(agg.gasPerRun, gasLeft)
+	}
+	agg.summarize()
+	return output, gasLeft, agg, nil
+}
+
+// runOnce drives execFunc for at least benchTime, looping if necessary,
+// and reports the per-call average time and allocation stats for that
+// run. A zero benchTime executes exactly once.
+func runOnce(execFunc func() ([]byte, uint64, error), benchTime time.Duration) (output []byte, gasLeft uint64, stats execStats, err error) {
+	var memBefore, memAfter goruntime.MemStats
+	goruntime.ReadMemStats(&memBefore)
+	start := time.Now()
+
+	n := 0
+	for {
+		output, gasLeft, err = execFunc()
+		n++
+		if err != nil || time.Since(start) >= benchTime {
+			break
+		}
+	}
+	elapsed := time.Since(start)
+	goruntime.ReadMemStats(&memAfter)
+
+	stats.time = elapsed / time.Duration(n)
+	stats.allocs = int64(memAfter.Mallocs-memBefore.Mallocs) / int64(n)
+	stats.bytesAllocated = int64(memAfter.TotalAlloc-memBefore.TotalAlloc) / int64(n)
+	return output, gasLeft, stats, err
+}
+
+func (agg *benchAggregate) summarize() {
+	ns := make([]int64, len(agg.runStats))
+	var gasSum float64
+	for i, s := range agg.runStats {
+		ns[i] = int64(s.time)
+	}
+	for _, g := range agg.gasPerRun {
+		gasSum += float64(g)
+	}
+	sort.Slice(ns, func(i, j int) bool { return ns[i] < ns[j] })
+
+	agg.MinNs = ns[0]
+	agg.MaxNs = ns[len(ns)-1]
+	agg.MedianNs = percentile(ns, 50)
+	agg.P95Ns = percentile(ns, 95)
+	if len(agg.gasPerRun) > 0 {
+		agg.MeanGas = gasSum / float64(len(agg.gasPerRun))
+	}
+}
+
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	idx := (len(sorted) - 1) * p / 100
+	return sorted[idx]
+}
+
+// writeStatsText renders a single run's execStats, or a benchAggregate if
+// one was produced by --bench-count, as the human-readable block that used
+// to be inlined in runCmd.
+func writeStatsText(w io.Writer, gasUsed uint64, single execStats, agg *benchAggregate) {
+	if agg != nil {
+		fmt.Fprintf(w, `EVM bench runs:   %d
+min exec time:    %v
+median exec time: %v
+p95 exec time:    %v
+max exec time:    %v
+mean gas used:    %.2f
+`, agg.Runs, time.Duration(agg.MinNs), time.Duration(agg.MedianNs), time.Duration(agg.P95Ns), time.Duration(agg.MaxNs), agg.MeanGas)
+		return
+	}
+	fmt.Fprintf(w, `EVM gas used:    %d
+execution time:  %v
+allocations:     %d
+allocated bytes: %d
+`, gasUsed, single.time, single.allocs, single.bytesAllocated)
+}
+
+// writeStatsJSON renders the same data as writeStatsText but as the
+// {gasUsed, execTimeNs, allocs, bytesAllocated, output, error} (or
+// benchAggregate) JSON shape consumed by CI harnesses and gas-regression
+// bots.
+func writeStatsJSON(w io.Writer, gasUsed uint64, output []byte, execErr error, single execStats, agg *benchAggregate) error {
+	var payload interface{}
+	if agg != nil {
+		payload = agg
+	} else {
+		errStr := ""
+		if execErr != nil {
+			errStr = execErr.Error()
+		}
+		payload = statsJSON{
+			GasUsed:        gasUsed,
+			ExecTimeNs:     int64(single.time),
+			Allocs:         single.allocs,
+			BytesAllocated: single.bytesAllocated,
+			Output:         fmt.Sprintf("%#x", output),
+			Error:          errStr,
+		}
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRunBenchNAggregatesAcrossRuns(t *testing.T) {
+	calls := 0
+	execFunc := func() ([]byte, uint64, error) {
+		calls++
+		return []byte{0x01}, uint64(calls), nil
+	}
+
+	output, gasLeft, agg, err := runBenchN(execFunc, 5, 0)
+	if err != nil {
+		t.Fatalf("runBenchN failed: %v", err)
+	}
+	if agg.Runs != 5 {
+		t.Errorf("runs mismatch: got %d, want 5", agg.Runs)
+	}
+	if calls != 5 {
+		t.Errorf("expected execFunc to be called once per run, got %d calls", calls)
+	}
+	if gasLeft != uint64(calls) {
+		t.Errorf("expected the final run's gas to be reported, got %d", gasLeft)
+	}
+	if !bytesEqual(output, []byte{0x01}) {
+		t.Errorf("output mismatch: got %x", output)
+	}
+	if agg.MinNs > agg.MedianNs || agg.MedianNs > agg.MaxNs {
+		t.Errorf("expected min <= median <= max, got %d/%d/%d", agg.MinNs, agg.MedianNs, agg.MaxNs)
+	}
+}
+
+func TestRunOnceRepeatsUntilBenchTime(t *testing.T) {
+	calls := 0
+	execFunc := func() ([]byte, uint64, error) {
+		calls++
+		return nil, 0, nil
+	}
+
+	if _, _, _, err := runOnce(execFunc, 10*time.Millisecond); err != nil {
+		t.Fatalf("runOnce failed: %v", err)
+	}
+	if calls < 2 {
+		t.Errorf("expected runOnce to loop past a single call for a non-zero benchTime, got %d calls", calls)
+	}
+}
+
+func TestWriteStatsJSONShape(t *testing.T) {
+	var buf bytes.Buffer
+	stats := execStats{time: 42 * time.Nanosecond, allocs: 3, bytesAllocated: 128}
+	if err := writeStatsJSON(&buf, 21000, []byte{0xca, 0xfe}, nil, stats, nil); err != nil {
+		t.Fatalf("writeStatsJSON failed: %v", err)
+	}
+
+	var decoded statsJSON
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded.GasUsed != 21000 {
+		t.Errorf("gasUsed mismatch: got %d, want 21000", decoded.GasUsed)
+	}
+	if decoded.ExecTimeNs != 42 {
+		t.Errorf("execTimeNs mismatch: got %d, want 42", decoded.ExecTimeNs)
+	}
+	if decoded.Output != "0xcafe" {
+		t.Errorf("output mismatch: got %q, want 0xcafe", decoded.Output)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/roodeag/arbitrum/common"
+	"github.com/roodeag/arbitrum/common/hexutil"
+	"github.com/roodeag/arbitrum/core/rawdb"
+	"github.com/roodeag/arbitrum/core/state"
+)
+
+func TestDumpState(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000001337")
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0x02")
+	// untracked is never passed to dumpState anywhere (there's no addrs
+	// parameter any more): it must be found by walking the full account
+	// trie, the way an account only reached via a nested CALL or a
+	// SELFDESTRUCT beneficiary would be.
+	untracked := common.HexToAddress("0x0000000000000000000000000000000000dead")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(addr)
+	statedb.SetBalance(addr, big.NewInt(1000))
+	statedb.SetNonce(addr, 5)
+	statedb.SetCode(addr, []byte{0x60, 0x00})
+	statedb.SetState(addr, slot, value)
+	statedb.CreateAccount(untracked)
+	statedb.SetBalance(untracked, big.NewInt(7))
+	statedb.Commit(true)
+
+	snapshot, err := dumpState(statedb)
+	if err != nil {
+		t.Fatalf("dumpState failed: %v", err)
+	}
+
+	account, ok := snapshot[addr]
+	if !ok {
+		t.Fatalf("expected %v in snapshot", addr)
+	}
+	if account.Balance.ToInt().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: got %v, want 1000", account.Balance.ToInt())
+	}
+	if account.Nonce != 5 {
+		t.Errorf("nonce mismatch: got %v, want 5", account.Nonce)
+	}
+	if !bytesEqual(account.Code, []byte{0x60, 0x00}) {
+		t.Errorf("code mismatch: got %x", account.Code)
+	}
+	if got := account.Storage[slot]; got != value {
+		t.Errorf("storage mismatch: got %v, want %v", got, value)
+	}
+
+	untrackedAccount, ok := snapshot[untracked]
+	if !ok {
+		t.Fatalf("expected untracked account %v to be captured by the full account-trie walk", untracked)
+	}
+	if untrackedAccount.Balance.ToInt().Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("balance mismatch: got %v, want 7", untrackedAccount.Balance.ToInt())
+	}
+}
+
+func TestWriteDumpOutRoundTrip(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000001337")
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0x02")
+
+	snapshot := Prestate{
+		addr: PrestateAccount{
+			Balance: (*hexutil.Big)(big.NewInt(1000)),
+			Nonce:   5,
+			Code:    []byte{0x60, 0x00},
+			Storage: map[common.Hash]common.Hash{slot: value},
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.json")
+	if err := writeDumpOut(path, snapshot); err != nil {
+		t.Fatalf("writeDumpOut failed: %v", err)
+	}
+
+	loaded, err := readPrestate(path)
+	if err != nil {
+		t.Fatalf("readPrestate failed: %v", err)
+	}
+	account, ok := loaded[addr]
+	if !ok {
+		t.Fatalf("expected %v in loaded snapshot", addr)
+	}
+	if account.Balance.ToInt().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: got %v, want 1000", account.Balance.ToInt())
+	}
+	if account.Storage[slot] != value {
+		t.Errorf("storage mismatch: got %v, want %v", account.Storage[slot], value)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/roodeag/arbitrum/params"
+)
+
+// forkActivations maps a fork name, as accepted by --fork and by the
+// "post" section of ethereum/tests state-test fixtures, to a ChainConfig
+// that activates every block/time-based fork up to and including it from
+// genesis. The table is ordered oldest-to-newest and each entry turns on
+// everything the previous entries turned on, so adding a new fork only
+// requires appending a row.
+var forkActivations = buildForkActivations()
+
+func buildForkActivations() map[string]*params.ChainConfig {
+	zero := big.NewInt(0)
+	base := &params.ChainConfig{ChainID: big.NewInt(1337)}
+
+	activate := func(cfg *params.ChainConfig) *params.ChainConfig {
+		c := *cfg
+		return &c
+	}
+
+	frontier := activate(base)
+
+	homestead := activate(frontier)
+	homestead.HomesteadBlock = zero
+
+	eip150 := activate(homestead)
+	eip150.EIP150Block = zero
+
+	eip158 := activate(eip150)
+	eip158.EIP155Block = zero
+	eip158.EIP158Block = zero
+
+	byzantium := activate(eip158)
+	byzantium.ByzantiumBlock = zero
+
+	constantinople := activate(byzantium)
+	constantinople.ConstantinopleBlock = zero
+	constantinople.PetersburgBlock = zero
+
+	istanbul := activate(constantinople)
+	istanbul.IstanbulBlock = zero
+
+	berlin := activate(istanbul)
+	berlin.MuirGlacierBlock = zero
+	berlin.BerlinBlock = zero
+
+	london := activate(berlin)
+	london.LondonBlock = zero
+
+	arbitrumOne := activate(london)
+
+	merge := activate(london)
+	merge.TerminalTotalDifficulty = zero
+
+	shanghai := activate(merge)
+	shanghai.ShanghaiTime = new(uint64)
+
+	cancun := activate(shanghai)
+	cancun.CancunTime = new(uint64)
+
+	return map[string]*params.ChainConfig{
+		"Frontier":       frontier,
+		"Homestead":      homestead,
+		"EIP150":         eip150,
+		"EIP158":         eip158,
+		"Byzantium":      byzantium,
+		"Constantinople": constantinople,
+		"Istanbul":       istanbul,
+		"Berlin":         berlin,
+		"London":         london,
+		"ArbitrumOne":    arbitrumOne,
+		"Merge":          merge,
+		"Shanghai":       shanghai,
+		"Cancun":         cancun,
+	}
+}
+
+// mostRecentFork is used as the default chain config when neither --fork
+// nor --genesis is given, so common opcodes (PUSH0, CHAINID, BASEFEE, ...)
+// work out of the box instead of failing against an empty ChainConfig.
+const mostRecentFork = "Cancun"
+
+// forkByName looks up the ChainConfig for the named fork, returning an
+// error that lists the valid names if it isn't found.
+func forkByName(name string) (*params.ChainConfig, error) {
+	cfg, ok := forkActivations[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fork %q, must be one of %v", name, forkNames())
+	}
+	return cfg, nil
+}
+
+func forkNames() []string {
+	names := make([]string, 0, len(forkActivations))
+	for name := range forkActivations {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import "testing"
+
+func TestForkByName(t *testing.T) {
+	cfg, err := forkByName("Cancun")
+	if err != nil {
+		t.Fatalf("forkByName(Cancun) failed: %v", err)
+	}
+	if cfg.CancunTime == nil {
+		t.Error("expected Cancun to be active")
+	}
+	if cfg.IstanbulBlock == nil || cfg.IstanbulBlock.Sign() != 0 {
+		t.Error("expected Cancun config to also activate earlier forks like Istanbul")
+	}
+}
+
+func TestForkByNameUnknown(t *testing.T) {
+	if _, err := forkByName("Quantum"); err == nil {
+		t.Error("expected an error for an unknown fork name")
+	}
+}
+
+func TestMostRecentForkIsRegistered(t *testing.T) {
+	if _, err := forkByName(mostRecentFork); err != nil {
+		t.Errorf("mostRecentFork %q is not a registered fork: %v", mostRecentFork, err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package compiler implements the tiny EASM assembler fed `.easm` files by
+// `evm run <file.easm>`. It is not a general-purpose assembler: it accepts
+// one instruction per line, in order, with no labels or macros, which is
+// enough for the small hand-written bytecode snippets the run command is
+// used to poke at.
+package compiler
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// opcodes maps every mnemonic EASM accepts, other than PUSH0-PUSH32,
+// DUP1-DUP16 and SWAP1-SWAP16 (filled in by init), to its compiled byte.
+// It covers the full EVM instruction set, not just the handful of
+// opcodes needed for simple hand-written snippets, so that fork-gated
+// opcodes like PUSH0/CHAINID/BASEFEE (see ForkFlag) can actually be
+// assembled.
+var opcodes = map[string]byte{
+	"STOP": 0x00, "ADD": 0x01, "MUL": 0x02, "SUB": 0x03, "DIV": 0x04, "SDIV": 0x05, "MOD": 0x06, "SMOD": 0x07,
+	"ADDMOD": 0x08, "MULMOD": 0x09, "EXP": 0x0a, "SIGNEXTEND": 0x0b,
+	"LT": 0x10, "GT": 0x11, "SLT": 0x12, "SGT": 0x13, "EQ": 0x14, "ISZERO": 0x15,
+	"AND": 0x16, "OR": 0x17, "XOR": 0x18, "NOT": 0x19, "BYTE": 0x1a, "SHL": 0x1b, "SHR": 0x1c, "SAR": 0x1d,
+	"SHA3":    0x20,
+	"ADDRESS": 0x30, "BALANCE": 0x31, "ORIGIN": 0x32, "CALLER": 0x33, "CALLVALUE": 0x34,
+	"CALLDATALOAD": 0x35, "CALLDATASIZE": 0x36, "CALLDATACOPY": 0x37,
+	"CODESIZE": 0x38, "CODECOPY": 0x39, "GASPRICE": 0x3a,
+	"EXTCODESIZE": 0x3b, "EXTCODECOPY": 0x3c, "RETURNDATASIZE": 0x3d, "RETURNDATACOPY": 0x3e, "EXTCODEHASH": 0x3f,
+	"BLOCKHASH": 0x40, "COINBASE": 0x41, "TIMESTAMP": 0x42, "NUMBER": 0x43,
+	"DIFFICULTY": 0x44, "GASLIMIT": 0x45, "CHAINID": 0x46, "SELFBALANCE": 0x47, "BASEFEE": 0x48,
+	"POP": 0x50, "MLOAD": 0x51, "MSTORE": 0x52, "MSTORE8": 0x53, "SLOAD": 0x54, "SSTORE": 0x55,
+	"PC": 0x58, "MSIZE": 0x59, "GAS": 0x5a, "JUMPDEST": 0x5b,
+	"LOG0": 0xa0, "LOG1": 0xa1, "LOG2": 0xa2, "LOG3": 0xa3, "LOG4": 0xa4,
+	"CREATE": 0xf0, "CALL": 0xf1, "CALLCODE": 0xf2,
+	"RETURN": 0xf3, "DELEGATECALL": 0xf4, "CREATE2": 0xf5,
+	"STATICCALL": 0xfa, "REVERT": 0xfd, "INVALID": 0xfe, "SELFDESTRUCT": 0xff,
+}
+
+// jumpOpcodes are the opcodes that, as a convenience, may take an
+// operand: "JUMP 0x0a" compiles to a PUSH of the destination followed by
+// JUMP, since every real use of JUMP/JUMPI in a hand-written snippet is
+// immediately preceded by one anyway.
+var jumpOpcodes = map[string]byte{
+	"JUMP": 0x56, "JUMPI": 0x57,
+}
+
+func init() {
+	opcodes["PUSH0"] = 0x5f
+	for i := 1; i <= 32; i++ {
+		opcodes[fmt.Sprintf("PUSH%d", i)] = byte(0x60 + i - 1)
+	}
+	for i := 1; i <= 16; i++ {
+		opcodes[fmt.Sprintf("DUP%d", i)] = byte(0x80 + i - 1)
+		opcodes[fmt.Sprintf("SWAP%d", i)] = byte(0x90 + i - 1)
+	}
+}
+
+// Compile assembles the given EASM source into EVM bytecode, returned as a
+// hex string without a leading "0x" (the shape runCmd passes straight into
+// common.Hex2Bytes). fn is only used to prefix error messages and --debug
+// output with the name of the originating file.
+func Compile(fn string, src []byte, debug bool) (string, error) {
+	var out []byte
+	for i, line := range strings.Split(string(src), "\n") {
+		// Error messages and --debug output are meant for a human looking
+		// at the .easm file in an editor, so the line counter is
+		// 1-indexed to match what every editor shows, not the 0-indexed
+		// position in the split slice.
+		lineNum := i + 1
+		instr, err := parseElementaryInstruction(line)
+		if err != nil {
+			return "", fmt.Errorf("%s:%d: %v", fn, lineNum, err)
+		}
+		if debug && instr != nil {
+			fmt.Printf("%s:%d: % x\n", fn, lineNum, instr)
+		}
+		out = append(out, instr...)
+	}
+	return fmt.Sprintf("%x", out), nil
+}
+
+// parseElementaryInstruction compiles a single line of EASM source into
+// its opcode bytes, or nil if the line has nothing to compile. A `;`
+// anywhere on the line begins a comment running to the end of the line, so
+// an operand and its explanation can share a line ("PUSH1 0x01 ; answer")
+// without the comment text being parsed as a second operand. A blank line,
+// or one that is entirely a comment, compiles to nothing.
+func parseElementaryInstruction(line string) ([]byte, error) {
+	if idx := strings.IndexByte(line, ';'); idx >= 0 {
+		line = line[:idx]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	mnemonic := strings.ToUpper(fields[0])
+	if op, ok := jumpOpcodes[mnemonic]; ok {
+		if len(fields) == 1 {
+			return []byte{op}, nil
+		}
+		dest, err := parseOperand(fields[1], mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		return append(pushBytes(dest), op), nil
+	}
+
+	op, ok := opcodes[mnemonic]
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction %q", fields[0])
+	}
+	if !strings.HasPrefix(mnemonic, "PUSH") || mnemonic == "PUSH0" {
+		if len(fields) > 1 {
+			return nil, fmt.Errorf("%s takes no operand, got %q", mnemonic, fields[1])
+		}
+		return []byte{op}, nil
+	}
+
+	size := int(op) - 0x60 + 1
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%s requires a %d-byte operand", mnemonic, size)
+	}
+	operand, err := parseOperand(fields[1], mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	if bits.Len64(operand) > size*8 {
+		return nil, fmt.Errorf("operand %q for %s does not fit in %d byte(s)", fields[1], mnemonic, size)
+	}
+	buf := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		buf[i] = byte(operand)
+		operand >>= 8
+	}
+	return append([]byte{op}, buf...), nil
+}
+
+// parseOperand parses a PUSH/JUMP/JUMPI operand, accepting either a
+// "0x"-prefixed base-16 literal or a bare base-10 one. On failure it wraps
+// the strconv error with the offending literal, the instruction it
+// belongs to and the base that was expected, instead of surfacing only
+// strconv's own message, which names neither the instruction nor the
+// expected base.
+func parseOperand(field, mnemonic string) (uint64, error) {
+	if lit, ok := stripHexPrefix(field); ok {
+		v, err := strconv.ParseUint(lit, 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid operand %q for %s (expected a base-16 literal): %v", field, mnemonic, err)
+		}
+		return v, nil
+	}
+	v, err := strconv.ParseUint(field, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid operand %q for %s (expected a base-10 literal): %v", field, mnemonic, err)
+	}
+	return v, nil
+}
+
+func stripHexPrefix(s string) (string, bool) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s[2:], true
+	}
+	return "", false
+}
+
+// pushBytes returns the minimal-width PUSH instruction (opcode plus
+// big-endian operand) for v, used to compile the implicit push ahead of a
+// bare "JUMP <dest>"/"JUMPI <dest>".
+func pushBytes(v uint64) []byte {
+	size := (bits.Len64(v) + 7) / 8
+	if size == 0 {
+		size = 1
+	}
+	buf := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		buf[i] = byte(v)
+		v >>= 8
+	}
+	return append([]byte{byte(0x60 + size - 1)}, buf...)
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import "testing"
+
+func TestCompileInlineComments(t *testing.T) {
+	src := `; a full-line comment is ignored
+PUSH1 0x01 ; the first operand
+PUSH1 0x02 ; the second operand
+ADD ; sum them
+`
+	bin, err := Compile("inline.easm", []byte(src), false)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	// PUSH1 0x01, PUSH1 0x02, ADD.
+	if want := "6001600201"; bin != want {
+		t.Errorf("bin mismatch: got %s, want %s", bin, want)
+	}
+}
+
+func TestCompileJumpWithImmediateOperand(t *testing.T) {
+	bin, err := Compile("jump.easm", []byte("JUMPDEST\nJUMP 0x05\n"), false)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	// JUMPDEST, then the implicit PUSH1 0x05, then JUMP.
+	if want := "5b600556"; bin != want {
+		t.Errorf("bin mismatch: got %s, want %s", bin, want)
+	}
+}
+
+func TestCompileMalformedPushNamesOffendingToken(t *testing.T) {
+	_, err := Compile("bad.easm", []byte("PUSH1 zork\n"), false)
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric PUSH operand")
+	}
+	if got := err.Error(); !contains(got, `"zork"`) || !contains(got, "base-10") {
+		t.Errorf("error should name the offending token and expected base, got: %v", got)
+	}
+}
+
+func TestCompileMalformedPushTooWide(t *testing.T) {
+	_, err := Compile("bad.easm", []byte("PUSH1 0x0100\n"), false)
+	if err == nil {
+		t.Fatal("expected an error for an operand wider than PUSH1 can hold")
+	}
+	if got := err.Error(); !contains(got, "does not fit") {
+		t.Errorf("expected a does-not-fit error, got: %v", got)
+	}
+}
+
+func TestCompileErrorReportsOneIndexedLineNumber(t *testing.T) {
+	src := "PUSH1 0x01\nPUSH1 0x02\nPUSH1 nope\n"
+	_, err := Compile("multiline.easm", []byte(src), false)
+	if err == nil {
+		t.Fatal("expected an error on line 3")
+	}
+	if got := err.Error(); !contains(got, "multiline.easm:3:") {
+		t.Errorf("expected the error to be attributed to line 3, got: %v", got)
+	}
+}
+
+func TestCompileFullOpcodeSet(t *testing.T) {
+	// One instruction from each family that was missing from the initial
+	// opcode table: arithmetic, comparison, bitwise, hashing, context,
+	// block info, logging, calls/creates and the no-operand PUSH0.
+	src := `PUSH0
+SHA3
+CALL
+DELEGATECALL
+STATICCALL
+CREATE2
+SELFDESTRUCT
+CHAINID
+BASEFEE
+LOG0
+SAR
+SLT
+`
+	bin, err := Compile("full.easm", []byte(src), false)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	want := "5f20f1f4fa" + "f5" + "ff" + "46" + "48" + "a0" + "1d" + "12"
+	if bin != want {
+		t.Errorf("bin mismatch: got %s, want %s", bin, want)
+	}
+}
+
+func contains(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
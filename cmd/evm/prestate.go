@@ -0,0 +1,78 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/roodeag/arbitrum/common"
+	"github.com/roodeag/arbitrum/common/hexutil"
+	"github.com/roodeag/arbitrum/core/state"
+)
+
+// PrestateAccount is the JSON representation of a single account entry in a
+// prestate allocation file. It uses the field names of the "pre" section of
+// the upstream ethereum/tests state-test fixtures. Numbers are decoded as
+// hexutil values, which reject leading zero digits ("0x00"), and storage
+// slots as full 32-byte hashes, so fixture entries written with leading
+// zeros or short slot keys are rejected.
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   hexutil.Uint64              `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// Prestate is a map of account address to its initial state, as read from a
+// --prestate JSON file.
+type Prestate map[common.Address]PrestateAccount
+
+// readPrestate reads and parses the given JSON file into a Prestate.
+func readPrestate(path string) (Prestate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prestate file: %v", err)
+	}
+	defer file.Close()
+
+	prestate := make(Prestate)
+	if err := json.NewDecoder(file).Decode(&prestate); err != nil {
+		return nil, fmt.Errorf("invalid prestate file: %v", err)
+	}
+	return prestate, nil
+}
+
+// Apply writes the accounts in the prestate into the given StateDB, creating
+// each account, setting its balance, nonce, code and storage slots.
+func (p Prestate) Apply(statedb *state.StateDB) {
+	for addr, account := range p {
+		statedb.CreateAccount(addr)
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		statedb.SetNonce(addr, uint64(account.Nonce))
+		if len(account.Code) > 0 {
+			statedb.SetCode(addr, account.Code)
+		}
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roodeag/arbitrum/common"
+	"github.com/roodeag/arbitrum/common/hexutil"
+	"github.com/roodeag/arbitrum/core/rawdb"
+	"github.com/roodeag/arbitrum/core/state"
+)
+
+func TestPrestateApply(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000001337")
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0x02")
+
+	prestate := Prestate{
+		addr: PrestateAccount{
+			Balance: (*hexutil.Big)(big.NewInt(1000)),
+			Nonce:   5,
+			Code:    []byte{0x60, 0x00},
+			Storage: map[common.Hash]common.Hash{slot: value},
+		},
+	}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	prestate.Apply(statedb)
+
+	if got := statedb.GetBalance(addr); got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: got %v, want 1000", got)
+	}
+	if got := statedb.GetNonce(addr); got != 5 {
+		t.Errorf("nonce mismatch: got %v, want 5", got)
+	}
+	if got := statedb.GetCode(addr); !bytesEqual(got, []byte{0x60, 0x00}) {
+		t.Errorf("code mismatch: got %x", got)
+	}
+	if got := statedb.GetState(addr, slot); got != value {
+		t.Errorf("storage mismatch: got %v, want %v", got, value)
+	}
+}
+
+func TestReadPrestateJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prestate.json")
+	data := []byte(`{
+		"0x0000000000000000000000000000000000001337": {
+			"balance": "0x3e8",
+			"nonce": "0x1",
+			"code": "0x6000",
+			"storage": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"
+			}
+		}
+	}`)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prestate, err := readPrestate(path)
+	if err != nil {
+		t.Fatalf("readPrestate failed: %v", err)
+	}
+	addr := common.HexToAddress("0x00000000000000000000000000000000001337")
+	account, ok := prestate[addr]
+	if !ok {
+		t.Fatalf("expected account %v in prestate", addr)
+	}
+	if account.Balance.ToInt().Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: got %v, want 1000", account.Balance.ToInt())
+	}
+	if account.Nonce != 1 {
+		t.Errorf("nonce mismatch: got %v, want 1", account.Nonce)
+	}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	prestate.Apply(statedb)
+	if got := statedb.GetBalance(addr); got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("applied balance mismatch: got %v", got)
+	}
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
@@ -23,9 +23,7 @@ import (
 	"io"
 	"math/big"
 	"os"
-	goruntime "runtime"
 	"runtime/pprof"
-	"testing"
 	"time"
 
 	"github.com/roodeag/arbitrum/cmd/evm/internal/compiler"
@@ -51,6 +49,55 @@ var runCommand = &cli.Command{
 	Description: `The run command runs arbitrary EVM code.`,
 }
 
+// PrestateFlag points at a JSON file describing an initial account
+// allocation (address -> balance/nonce/code/storage) to load into the
+// StateDB before execution. It is orthogonal to --genesis, which only
+// configures chain rules and block context.
+var PrestateFlag = &cli.StringFlag{
+	Name:  "prestate",
+	Usage: "Path to a JSON file with the prestate (account allocation) to preload",
+}
+
+// ForkFlag selects a named fork (see forks.go) and overrides whatever
+// --genesis provided with a ChainConfig that activates that fork, and
+// everything before it, from block/time zero.
+var ForkFlag = &cli.StringFlag{
+	Name:  "fork",
+	Usage: "Hardfork to run as if active from genesis, e.g. Istanbul, Berlin, London, Merge, Shanghai, Cancun, ArbitrumOne",
+}
+
+// StatsFormatFlag switches the stats block printed by --bench/--statdump from
+// human-readable text to the {gasUsed, execTimeNs, ...} JSON shape a CI
+// harness can consume directly.
+var StatsFormatFlag = &cli.StringFlag{
+	Name:  "stats-format",
+	Usage: `Format for the execution/benchmark stats block: "text" (default) or "json"`,
+	Value: "text",
+}
+
+// StatsOutFlag redirects the --stats-format=json output to a file instead
+// of stdout.
+var StatsOutFlag = &cli.StringFlag{
+	Name:  "stats-out",
+	Usage: "File to write --stats-format=json output to (default: stdout)",
+}
+
+// BenchCountFlag drives multiple --bench runs so timing variance (not just
+// a single averaged sample) can be reported.
+var BenchCountFlag = &cli.IntFlag{
+	Name:  "bench-count",
+	Usage: "Number of independent benchmark runs to aggregate (implies --bench)",
+	Value: 1,
+}
+
+// BenchTimeFlag sets the minimum wall-clock duration each --bench-count run
+// repeats execFunc for, analogous to `go test -benchtime`.
+var BenchTimeFlag = &cli.DurationFlag{
+	Name:  "bench-time",
+	Usage: "Minimum duration to run each benchmark iteration for (implies --bench)",
+	Value: time.Second,
+}
+
 // readGenesis will read the given JSON format genesis file and return
 // the initialized Genesis structure
 func readGenesis(genesisPath string) *core.Genesis {
@@ -78,33 +125,6 @@ type execStats struct {
 	bytesAllocated int64         // The cumulative number of bytes allocated during execution.
 }
 
-func timedExec(bench bool, execFunc func() ([]byte, uint64, error)) (output []byte, gasLeft uint64, stats execStats, err error) {
-	if bench {
-		result := testing.Benchmark(func(b *testing.B) {
-			for i := 0; i < b.N; i++ {
-				output, gasLeft, err = execFunc()
-			}
-		})
-
-		// Get the average execution time from the benchmarking result.
-		// There are other useful stats here that could be reported.
-		stats.time = time.Duration(result.NsPerOp())
-		stats.allocs = result.AllocsPerOp()
-		stats.bytesAllocated = result.AllocedBytesPerOp()
-	} else {
-		var memStatsBefore, memStatsAfter goruntime.MemStats
-		goruntime.ReadMemStats(&memStatsBefore)
-		startTime := time.Now()
-		output, gasLeft, err = execFunc()
-		stats.time = time.Since(startTime)
-		goruntime.ReadMemStats(&memStatsAfter)
-		stats.allocs = int64(memStatsAfter.Mallocs - memStatsBefore.Mallocs)
-		stats.bytesAllocated = int64(memStatsAfter.TotalAlloc - memStatsBefore.TotalAlloc)
-	}
-
-	return output, gasLeft, stats, err
-}
-
 func runCmd(ctx *cli.Context) error {
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
 	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
@@ -154,6 +174,24 @@ func runCmd(ctx *cli.Context) error {
 		receiver = common.HexToAddress(ctx.String(ReceiverFlag.Name))
 	}
 
+	loadSnapshot := func(path string) {
+		snapshot, err := readPrestate(path)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		snapshot.Apply(statedb)
+	}
+	if prestatePath := ctx.String(PrestateFlag.Name); prestatePath != "" {
+		// sender was already CreateAccount'd above, so if the prestate
+		// allocation has an entry for it, applying the snapshot is what
+		// funds it; otherwise it keeps the zero balance that behavior
+		// without --prestate also gives it.
+		loadSnapshot(prestatePath)
+	}
+	if loadStatePath := ctx.String(LoadStateFlag.Name); loadStatePath != "" {
+		loadSnapshot(loadStatePath)
+	}
+
 	var code []byte
 	codeFileFlag := ctx.String(CodeFileFlag.Name)
 	codeFlag := ctx.String(CodeFlag.Name)
@@ -231,10 +269,17 @@ func runCmd(ctx *cli.Context) error {
 		defer pprof.StopCPUProfile()
 	}
 
-	if chainConfig != nil {
+	if forkName := ctx.String(ForkFlag.Name); forkName != "" {
+		cfg, err := forkByName(forkName)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		runtimeConfig.ChainConfig = cfg
+	} else if chainConfig != nil {
 		runtimeConfig.ChainConfig = chainConfig
 	} else {
-		runtimeConfig.ChainConfig = params.AllEthashProtocolChanges
+		cfg, _ := forkByName(mostRecentFork)
+		runtimeConfig.ChainConfig = cfg
 	}
 
 	var hexInput []byte
@@ -271,13 +316,49 @@ func runCmd(ctx *cli.Context) error {
 	}
 
 	bench := ctx.Bool(BenchFlag.Name)
-	output, leftOverGas, stats, err := timedExec(bench, execFunc)
+	benchCount := ctx.Int(BenchCountFlag.Name)
+	if ctx.IsSet(BenchCountFlag.Name) || ctx.IsSet(BenchTimeFlag.Name) {
+		bench = true
+	}
 
-	if ctx.Bool(DumpFlag.Name) {
+	var (
+		stats       execStats
+		agg         *benchAggregate
+		output      []byte
+		leftOverGas uint64
+		err         error
+	)
+	if bench && benchCount > 1 {
+		output, leftOverGas, agg, err = runBenchN(execFunc, benchCount, ctx.Duration(BenchTimeFlag.Name))
+		if err == nil {
+			// runBenchN averages the gas left after each run; report gas used.
+			agg.MeanGas = float64(initialGas) - agg.MeanGas
+		}
+	} else {
+		var benchTime time.Duration
+		if bench {
+			benchTime = ctx.Duration(BenchTimeFlag.Name)
+		}
+		output, leftOverGas, stats, err = runOnce(execFunc, benchTime)
+	}
+
+	dumpOutPath := ctx.String(DumpOutFlag.Name)
+	if ctx.Bool(DumpFlag.Name) || dumpOutPath != "" {
 		statedb.Commit(true)
 		statedb.IntermediateRoot(true)
+	}
+	if ctx.Bool(DumpFlag.Name) {
 		fmt.Println(string(statedb.Dump(nil)))
 	}
+	if dumpOutPath != "" {
+		snapshot, err := dumpState(statedb)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		if err := writeDumpOut(dumpOutPath, snapshot); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
 
 	if memProfilePath := ctx.String(MemProfileFlag.Name); memProfilePath != "" {
 		f, err := os.Create(memProfilePath)
@@ -302,11 +383,28 @@ func runCmd(ctx *cli.Context) error {
 	}
 
 	if bench || ctx.Bool(StatDumpFlag.Name) {
-		fmt.Fprintf(os.Stderr, `EVM gas used:    %d
-execution time:  %v
-allocations:     %d
-allocated bytes: %d
-`, initialGas-leftOverGas, stats.time, stats.allocs, stats.bytesAllocated)
+		statsOut := io.Writer(os.Stderr)
+		if ctx.String(StatsFormatFlag.Name) == "json" {
+			statsOut = os.Stdout
+		}
+		if statsOutPath := ctx.String(StatsOutFlag.Name); statsOutPath != "" {
+			f, err := os.Create(statsOutPath)
+			if err != nil {
+				fmt.Println("could not create stats output file: ", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			statsOut = f
+		}
+		gasUsed := initialGas - leftOverGas
+		if ctx.String(StatsFormatFlag.Name) == "json" {
+			if err := writeStatsJSON(statsOut, gasUsed, output, err, stats, agg); err != nil {
+				fmt.Println("could not write stats: ", err)
+				os.Exit(1)
+			}
+		} else {
+			writeStatsText(statsOut, gasUsed, stats, agg)
+		}
 	}
 	if tracer == nil {
 		fmt.Printf("%#x\n", output)
@@ -0,0 +1,297 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/roodeag/arbitrum/common"
+	"github.com/roodeag/arbitrum/common/hexutil"
+	"github.com/roodeag/arbitrum/common/math"
+	"github.com/roodeag/arbitrum/core/rawdb"
+	"github.com/roodeag/arbitrum/core/state"
+	"github.com/roodeag/arbitrum/core/vm"
+	"github.com/roodeag/arbitrum/core/vm/runtime"
+	"github.com/roodeag/arbitrum/crypto"
+	"github.com/roodeag/arbitrum/eth/tracers/logger"
+	"github.com/roodeag/arbitrum/log"
+	"github.com/roodeag/arbitrum/params"
+	"github.com/urfave/cli/v2"
+)
+
+var stateTestCommand = &cli.Command{
+	Action:    stateTestCmd,
+	Name:      "statetest",
+	Usage:     "executes the given state tests",
+	ArgsUsage: "<file>",
+	Description: `The statetest command runs one or more Ethereum state tests in the
+standard ethereum/tests JSON format against every fork listed in the fixture
+and reports whether the resulting post-state root matches the one recorded
+in the fixture.`,
+}
+
+// stateTestFixture is a single named entry in a state-test JSON file. The
+// file itself is a map of test name to fixture, matching the upstream
+// ethereum/tests layout. Pre is decoded as a Prestate, so it takes that
+// type's stricter encoding of numbers and storage slots (see PrestateAccount).
+type stateTestFixture struct {
+	Env  stateTestEnv                    `json:"env"`
+	Pre  Prestate                        `json:"pre"`
+	Tx   stateTestTransaction            `json:"transaction"`
+	Post map[string][]stateTestPostEntry `json:"post"`
+}
+
+type stateTestEnv struct {
+	Coinbase   common.Address        `json:"currentCoinbase"`
+	Difficulty *math.HexOrDecimal256 `json:"currentDifficulty"`
+	GasLimit   math.HexOrDecimal64   `json:"currentGasLimit"`
+	Number     math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp  math.HexOrDecimal64   `json:"currentTimestamp"`
+}
+
+type stateTestTransaction struct {
+	GasPrice   *math.HexOrDecimal256 `json:"gasPrice"`
+	Nonce      math.HexOrDecimal64   `json:"nonce"`
+	To         string                `json:"to"`
+	Data       []hexutil.Bytes       `json:"data"`
+	GasLimit   []math.HexOrDecimal64 `json:"gasLimit"`
+	Value      []string              `json:"value"`
+	PrivateKey hexutil.Bytes         `json:"secretKey"`
+}
+
+type stateTestPostEntry struct {
+	RootHash common.Hash `json:"hash"`
+	Indexes  struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+}
+
+// stateTestResult is one entry of the JSON array emitted by the statetest
+// command: the outcome of running a single (test, fork, index) combination.
+type stateTestResult struct {
+	Name      string `json:"name"`
+	Fork      string `json:"fork"`
+	Index     int    `json:"index"`
+	Pass      bool   `json:"pass"`
+	StateRoot string `json:"stateRoot"`
+	Expected  string `json:"expected"`
+	Error     string `json:"error,omitempty"`
+}
+
+func stateTestCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() == 0 {
+		return fmt.Errorf("path to at least one state test JSON file is required")
+	}
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	logconfig := &logger.Config{
+		EnableMemory:     !ctx.Bool(DisableMemoryFlag.Name),
+		DisableStack:     ctx.Bool(DisableStackFlag.Name),
+		DisableStorage:   ctx.Bool(DisableStorageFlag.Name),
+		EnableReturnData: !ctx.Bool(DisableReturnDataFlag.Name),
+		Debug:            ctx.Bool(DebugFlag.Name),
+	}
+
+	var results []stateTestResult
+	failed := false
+	for _, path := range ctx.Args().Slice() {
+		fileResults, err := runStateTestFile(path, logconfig, ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		results = append(results, fileResults...)
+	}
+	for _, r := range results {
+		if !r.Pass {
+			failed = true
+		}
+	}
+
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runStateTestFile(path string, logconfig *logger.Config, ctx *cli.Context) ([]stateTestResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state test file: %v", err)
+	}
+	defer file.Close()
+
+	var fixtures map[string]stateTestFixture
+	if err := json.NewDecoder(file).Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("invalid state test file: %v", err)
+	}
+
+	var results []stateTestResult
+	for name, fixture := range fixtures {
+		for forkName, posts := range fixture.Post {
+			chainConfig, err := forkByName(forkName)
+			if err != nil {
+				results = append(results, stateTestResult{
+					Name:  name,
+					Fork:  forkName,
+					Error: err.Error(),
+				})
+				continue
+			}
+			for idx, post := range posts {
+				result := runStateTestCase(name, forkName, idx, fixture, post, chainConfig, logconfig, ctx)
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+func runStateTestCase(name, forkName string, idx int, fixture stateTestFixture, post stateTestPostEntry, chainConfig *params.ChainConfig, logconfig *logger.Config, ctx *cli.Context) stateTestResult {
+	result := stateTestResult{
+		Name:     name,
+		Fork:     forkName,
+		Index:    idx,
+		Expected: post.RootHash.Hex(),
+	}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	fixture.Pre.Apply(statedb)
+
+	var tracer vm.EVMLogger
+	if ctx.Bool(DebugFlag.Name) || ctx.Bool(MachineFlag.Name) {
+		tracer = logger.NewStructLogger(logconfig)
+	}
+
+	sender, err := stateTestSender(fixture)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	value, err := stateTestTxValue(fixture, post.Indexes.Value)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	gasLimit, err := stateTestTxGasLimit(fixture, post.Indexes.Gas)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	data, err := stateTestTxData(fixture, post.Indexes.Data)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	runtimeConfig := &runtime.Config{
+		Origin:      sender,
+		State:       statedb,
+		GasLimit:    gasLimit,
+		GasPrice:    (*big.Int)(fixture.Tx.GasPrice),
+		Value:       value,
+		Difficulty:  (*big.Int)(fixture.Env.Difficulty),
+		Time:        uint64(fixture.Env.Timestamp),
+		Coinbase:    fixture.Env.Coinbase,
+		BlockNumber: new(big.Int).SetUint64(uint64(fixture.Env.Number)),
+		ChainConfig: chainConfig,
+		EVMConfig: vm.Config{
+			Tracer: tracer,
+			Debug:  tracer != nil,
+		},
+	}
+
+	input := []byte(data)
+	if fixture.Tx.To == "" {
+		_, _, _, err = runtime.Create(input, runtimeConfig)
+	} else {
+		_, _, err = runtime.Call(common.HexToAddress(fixture.Tx.To), input, runtimeConfig)
+	}
+
+	statedb.Commit(true)
+	root := statedb.IntermediateRoot(true)
+	result.StateRoot = root.Hex()
+	result.Pass = err == nil && root == post.RootHash
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// stateTestSender recovers the transaction sender address from the
+// fixture's secretKey, the way upstream ethereum/tests fixtures identify
+// the account in "pre" that funds the transaction. Zero-filling the sender
+// instead would almost never match the funded account, so this is required
+// for the subcommand to be a faithful conformance harness.
+func stateTestSender(fixture stateTestFixture) (common.Address, error) {
+	if len(fixture.Tx.PrivateKey) == 0 {
+		return common.Address{}, fmt.Errorf("state test transaction is missing secretKey")
+	}
+	key, err := crypto.ToECDSA(fixture.Tx.PrivateKey)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid secretKey: %v", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+// stateTestTxValue decodes the indexed transaction value, as selected by
+// post.Indexes.Value, into a big.Int suitable for runtime.Config.Value.
+func stateTestTxValue(fixture stateTestFixture, index int) (*big.Int, error) {
+	if index < 0 || index >= len(fixture.Tx.Value) {
+		return nil, fmt.Errorf("value index %d out of range", index)
+	}
+	value := fixture.Tx.Value[index]
+	if value == "" || value == "0x" {
+		return new(big.Int), nil
+	}
+	v, ok := math.ParseBig256(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+// stateTestTxGasLimit bounds-checks the indexed gas limit the same way
+// stateTestTxValue does, so a malformed fixture with an out-of-range
+// post.indexes.gas produces a {..., error} result entry instead of a panic.
+func stateTestTxGasLimit(fixture stateTestFixture, index int) (uint64, error) {
+	if index < 0 || index >= len(fixture.Tx.GasLimit) {
+		return 0, fmt.Errorf("gas index %d out of range", index)
+	}
+	return uint64(fixture.Tx.GasLimit[index]), nil
+}
+
+// stateTestTxData bounds-checks the indexed calldata the same way
+// stateTestTxValue does, so a malformed fixture with an out-of-range
+// post.indexes.data produces a {..., error} result entry instead of a panic.
+func stateTestTxData(fixture stateTestFixture, index int) (hexutil.Bytes, error) {
+	if index < 0 || index >= len(fixture.Tx.Data) {
+		return nil, fmt.Errorf("data index %d out of range", index)
+	}
+	return fixture.Tx.Data[index], nil
+}
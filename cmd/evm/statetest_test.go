@@ -0,0 +1,154 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/roodeag/arbitrum/common"
+	"github.com/roodeag/arbitrum/common/hexutil"
+	"github.com/roodeag/arbitrum/common/math"
+)
+
+func TestForkByNameInStateTest(t *testing.T) {
+	if _, err := forkByName("Istanbul"); err != nil {
+		t.Errorf("expected Istanbul to be a recognized fork: %v", err)
+	}
+	if _, err := forkByName("NotAFork"); err == nil {
+		t.Error("expected NotAFork to be unrecognized")
+	}
+}
+
+func TestDecodeStateTestFixture(t *testing.T) {
+	data := []byte(`{
+		"exampleTest": {
+			"env": {
+				"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+				"currentDifficulty": "0x20000",
+				"currentGasLimit": "0x7fffffffffffffff",
+				"currentNumber": "0x1",
+				"currentTimestamp": "0x3e8"
+			},
+			"pre": {},
+			"transaction": {
+				"gasPrice": "0x01",
+				"nonce": "0x00",
+				"to": "",
+				"data": ["0x"],
+				"gasLimit": ["0x0186a0"],
+				"value": ["0x00"],
+				"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d"
+			},
+			"post": {
+				"Istanbul": [
+					{"hash": "0x0000000000000000000000000000000000000000000000000000000000000000", "indexes": {"data": 0, "gas": 0, "value": 0}}
+				]
+			}
+		}
+	}`)
+
+	var fixtures map[string]stateTestFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+	fixture, ok := fixtures["exampleTest"]
+	if !ok {
+		t.Fatal("expected exampleTest in decoded fixtures")
+	}
+	if len(fixture.Post["Istanbul"]) != 1 {
+		t.Fatalf("expected one Istanbul post entry, got %d", len(fixture.Post["Istanbul"]))
+	}
+}
+
+func TestStateTestSender(t *testing.T) {
+	fixture := stateTestFixture{
+		Tx: stateTestTransaction{
+			PrivateKey: common.Hex2Bytes("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d"),
+		},
+	}
+	sender, err := stateTestSender(fixture)
+	if err != nil {
+		t.Fatalf("stateTestSender failed: %v", err)
+	}
+	want := common.HexToAddress("0x7fd3aedd80f6286b769f9dee22d2c08407f76cf5")
+	if sender != want {
+		t.Errorf("sender mismatch: got %v, want %v", sender, want)
+	}
+
+	if _, err := stateTestSender(stateTestFixture{}); err == nil {
+		t.Error("expected error for missing secretKey")
+	}
+}
+
+func TestStateTestTxValue(t *testing.T) {
+	fixture := stateTestFixture{
+		Tx: stateTestTransaction{
+			Value: []string{"0x00", "0x0a"},
+		},
+	}
+	value, err := stateTestTxValue(fixture, 1)
+	if err != nil {
+		t.Fatalf("stateTestTxValue failed: %v", err)
+	}
+	if value.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("value mismatch: got %v, want 10", value)
+	}
+
+	if _, err := stateTestTxValue(fixture, 5); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+}
+
+func TestStateTestTxGasLimit(t *testing.T) {
+	fixture := stateTestFixture{
+		Tx: stateTestTransaction{
+			GasLimit: []math.HexOrDecimal64{0x5208, 0x0186a0},
+		},
+	}
+	gasLimit, err := stateTestTxGasLimit(fixture, 1)
+	if err != nil {
+		t.Fatalf("stateTestTxGasLimit failed: %v", err)
+	}
+	if gasLimit != 0x0186a0 {
+		t.Errorf("gas limit mismatch: got %v, want %v", gasLimit, uint64(0x0186a0))
+	}
+
+	if _, err := stateTestTxGasLimit(fixture, 5); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+}
+
+func TestStateTestTxData(t *testing.T) {
+	fixture := stateTestFixture{
+		Tx: stateTestTransaction{
+			Data: []hexutil.Bytes{{}, {0x60, 0x00}},
+		},
+	}
+	data, err := stateTestTxData(fixture, 1)
+	if err != nil {
+		t.Fatalf("stateTestTxData failed: %v", err)
+	}
+	if !bytesEqual(data, []byte{0x60, 0x00}) {
+		t.Errorf("data mismatch: got %x", data)
+	}
+
+	if _, err := stateTestTxData(fixture, 5); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+}